@@ -0,0 +1,64 @@
+// Package iap provides a store-agnostic purchase verification abstraction
+// on top of go-iap's store-specific packages (playstore, and in the future
+// appstore and amazon).
+package iap
+
+import "context"
+
+// PurchaseRequest identifies a single purchase to verify or acknowledge.
+// Which fields are required depends on the backing Verifier; for the Play
+// Store adapter, PackageName, ProductID and Token are all required.
+type PurchaseRequest struct {
+	// PackageName is the Android package name or iOS bundle ID the purchase
+	// was made in.
+	PackageName string
+
+	// ProductID is the SKU/product ID (or subscription ID) the purchase is
+	// for.
+	ProductID string
+
+	// Token is the purchase token, receipt, or transaction identifier the
+	// store issued to the client.
+	Token string
+
+	// IsSubscription distinguishes a subscription purchase from a
+	// one-time/consumable product purchase, since most stores expose them
+	// through different APIs.
+	IsSubscription bool
+}
+
+// NormalizedPurchase is a store-agnostic view of a verified purchase. Fields
+// that don't apply to a given store/purchase type are left at their zero
+// value.
+type NormalizedPurchase struct {
+	// TransactionID identifies this specific transaction (e.g. the Play
+	// order ID, or the App Store transaction ID).
+	TransactionID string
+
+	// OriginalTransactionID identifies the first transaction in a renewal
+	// chain. For non-renewing purchases this equals TransactionID.
+	OriginalTransactionID string
+
+	ProductID string
+	Quantity  int64
+
+	PurchaseTimeMs int64
+	ExpiresAtMs    int64
+
+	AutoRenewing bool
+	IsTrial      bool
+
+	// Environment is "production" or "sandbox", as reported by the store.
+	Environment string
+
+	// Raw is the store-specific response VerifyPurchase normalized, e.g. a
+	// *androidpublisher.SubscriptionPurchase.
+	Raw interface{}
+}
+
+// Verifier verifies and acknowledges purchases against a single store,
+// normalizing its responses into NormalizedPurchase.
+type Verifier interface {
+	VerifyPurchase(ctx context.Context, req PurchaseRequest) (*NormalizedPurchase, error)
+	Acknowledge(ctx context.Context, req PurchaseRequest) error
+}
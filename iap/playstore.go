@@ -0,0 +1,109 @@
+package iap
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/Cvarog/go-iap/playstore"
+	androidpublisher "google.golang.org/api/androidpublisher/v3"
+)
+
+// playStoreTestPurchaseType is the PurchaseType value androidpublisher uses
+// to flag a purchase made via a license testing account.
+const playStoreTestPurchaseType = 0
+
+// PlayStoreVerifier is a Verifier backed by a playstore.Client.
+type PlayStoreVerifier struct {
+	client *playstore.Client
+}
+
+// NewPlayStoreVerifier returns a Verifier that verifies and acknowledges
+// purchases against the Play Store using client.
+func NewPlayStoreVerifier(client *playstore.Client) *PlayStoreVerifier {
+	return &PlayStoreVerifier{client: client}
+}
+
+// VerifyPurchase implements Verifier.
+func (v *PlayStoreVerifier) VerifyPurchase(ctx context.Context, req PurchaseRequest) (*NormalizedPurchase, error) {
+	if req.IsSubscription {
+		purchase, err := v.client.VerifySubscription(ctx, req.PackageName, req.ProductID, req.Token)
+		if err != nil {
+			return nil, err
+		}
+		return normalizeSubscriptionPurchase(req, purchase), nil
+	}
+
+	purchase, err := v.client.VerifyProduct(ctx, req.PackageName, req.ProductID, req.Token)
+	if err != nil {
+		return nil, err
+	}
+	return normalizeProductPurchase(req, purchase), nil
+}
+
+// Acknowledge implements Verifier.
+func (v *PlayStoreVerifier) Acknowledge(ctx context.Context, req PurchaseRequest) error {
+	if req.IsSubscription {
+		return v.client.AcknowledgeSubscription(ctx, req.PackageName, req.ProductID, req.Token, &androidpublisher.SubscriptionPurchasesAcknowledgeRequest{})
+	}
+
+	return v.client.AcknowledgeProduct(ctx, req.PackageName, req.ProductID, req.Token, &androidpublisher.ProductPurchasesAcknowledgeRequest{})
+}
+
+func normalizeSubscriptionPurchase(req PurchaseRequest, purchase *androidpublisher.SubscriptionPurchase) *NormalizedPurchase {
+	return &NormalizedPurchase{
+		TransactionID:         purchase.OrderId,
+		OriginalTransactionID: originalSubscriptionOrderID(purchase.OrderId),
+		ProductID:             req.ProductID,
+		Quantity:              1,
+		PurchaseTimeMs:        parseMillis(purchase.StartTimeMillis),
+		ExpiresAtMs:           parseMillis(purchase.ExpiryTimeMillis),
+		AutoRenewing:          purchase.AutoRenewing,
+		IsTrial:               purchase.PaymentState != nil && *purchase.PaymentState == 2,
+		Environment:           playStoreEnvironment(purchase.PurchaseType),
+		Raw:                   purchase,
+	}
+}
+
+func normalizeProductPurchase(req PurchaseRequest, purchase *androidpublisher.ProductPurchase) *NormalizedPurchase {
+	return &NormalizedPurchase{
+		TransactionID:         purchase.OrderId,
+		OriginalTransactionID: purchase.OrderId,
+		ProductID:             req.ProductID,
+		Quantity:              purchase.Quantity,
+		PurchaseTimeMs:        parseMillis(purchase.PurchaseTimeMillis),
+		Environment:           playStoreEnvironment(purchase.PurchaseType),
+		Raw:                   purchase,
+	}
+}
+
+// playStoreEnvironment maps androidpublisher's purchaseType field (nil for a
+// normal purchase, 0 for a license tester, 1 for a promo code redemption)
+// onto the store-agnostic "production"/"sandbox" distinction.
+func playStoreEnvironment(purchaseType *int64) string {
+	if purchaseType != nil && *purchaseType == playStoreTestPurchaseType {
+		return "sandbox"
+	}
+	return "production"
+}
+
+// originalSubscriptionOrderID derives the first order in a renewal chain
+// from a Play Store subscription order ID. Renewal orders are suffixed with
+// ".." followed by the renewal index (e.g. "GPA.1234-5678..0", "..1", "..2"),
+// so the original order is the bare base order ID shared by the whole chain.
+// orderID is returned unchanged if it carries no renewal suffix.
+func originalSubscriptionOrderID(orderID string) string {
+	base, _, ok := strings.Cut(orderID, "..")
+	if !ok {
+		return orderID
+	}
+	return base
+}
+
+func parseMillis(s string) int64 {
+	ms, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return ms
+}
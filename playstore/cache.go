@@ -0,0 +1,137 @@
+package playstore
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Cache is a pluggable store for verification results, keyed by
+// packageName+productID/subscriptionID+token. Implementations must be safe
+// for concurrent use.
+type Cache interface {
+	// Get returns the cached value for key and whether it was found. A
+	// found entry past its TTL must behave as a miss.
+	Get(key string) ([]byte, bool)
+
+	// Set stores value under key for the given ttl. A ttl of zero means the
+	// entry never expires on its own.
+	Set(key string, value []byte, ttl time.Duration)
+}
+
+// Default TTLs used when caching VerifyProduct/VerifySubscription results.
+// Purchases that haven't been acknowledged yet are re-checked often, since
+// acknowledgement state can change quickly after purchase; once acknowledged
+// the result is stable for much longer.
+const (
+	defaultUnacknowledgedCacheTTL = 30 * time.Second
+	defaultAcknowledgedCacheTTL   = 10 * time.Minute
+)
+
+// lruEntry is the value stored in LRUCache's backing list.
+type lruEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// LRUCache is an in-memory Cache with a fixed capacity and per-entry TTLs.
+// When full, the least recently used entry is evicted to make room.
+type LRUCache struct {
+	mu       sync.Mutex
+	capacity int
+	ll       *list.List
+	items    map[string]*list.Element
+}
+
+// NewLRUCache returns an LRUCache holding at most capacity entries. A
+// capacity of zero or less means the cache has no size limit.
+func NewLRUCache(capacity int) *LRUCache {
+	return &LRUCache{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element, capacity),
+	}
+}
+
+// Get implements Cache.
+func (c *LRUCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := elem.Value.(*lruEntry)
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		c.ll.Remove(elem)
+		delete(c.items, key)
+		return nil, false
+	}
+
+	c.ll.MoveToFront(elem)
+	return entry.value, true
+}
+
+// Set implements Cache.
+func (c *LRUCache) Set(key string, value []byte, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl)
+	}
+
+	if elem, ok := c.items[key]; ok {
+		c.ll.MoveToFront(elem)
+		elem.Value.(*lruEntry).value = value
+		elem.Value.(*lruEntry).expiresAt = expiresAt
+		return
+	}
+
+	elem := c.ll.PushFront(&lruEntry{key: key, value: value, expiresAt: expiresAt})
+	c.items[key] = elem
+
+	if c.capacity > 0 && c.ll.Len() > c.capacity {
+		oldest := c.ll.Back()
+		if oldest != nil {
+			c.ll.Remove(oldest)
+			delete(c.items, oldest.Value.(*lruEntry).key)
+		}
+	}
+}
+
+// SetCache wires cache into the Client so VerifyProduct and
+// VerifySubscription serve repeated verifications of the same
+// (packageName, productID/subscriptionID, token) from cache instead of
+// calling the Play API, and coalesce concurrent in-flight verifications for
+// the same key.
+func (c *Client) SetCache(cache Cache) {
+	c.cache = cache
+}
+
+// A Redis-backed Cache adapter can be built on any client you already use,
+// since Cache only needs Get/Set. Using github.com/redis/go-redis/v9:
+//
+//	type RedisCache struct {
+//		rdb *redis.Client
+//	}
+//
+//	func (r *RedisCache) Get(key string) ([]byte, bool) {
+//		value, err := r.rdb.Get(context.Background(), key).Bytes()
+//		if err != nil {
+//			return nil, false
+//		}
+//		return value, true
+//	}
+//
+//	func (r *RedisCache) Set(key string, value []byte, ttl time.Duration) {
+//		r.rdb.Set(context.Background(), key, value, ttl)
+//	}
+
+func cacheKey(packageName, id, token string) string {
+	return packageName + ":" + id + ":" + token
+}
@@ -0,0 +1,144 @@
+package playstore
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/oauth2"
+	"google.golang.org/api/googleapi"
+)
+
+// APIError is a typed error produced by mapping the HTTP status and
+// googleapi.Error.Errors[].Reason fields androidpublisher returns. Compare
+// against the Err* sentinels below with errors.Is; the underlying
+// *googleapi.Error is still reachable via errors.As / Unwrap.
+type APIError struct {
+	// Kind identifies which sentinel this error matches.
+	Kind string
+	// StatusCode is the HTTP status code returned by the API, if known.
+	StatusCode int
+	// Reason is the googleapi.Error reason string, if any.
+	Reason string
+
+	err error
+}
+
+func (e *APIError) Error() string {
+	if e.Reason != "" {
+		return fmt.Sprintf("playstore: %s (status %d, reason %q)", e.Kind, e.StatusCode, e.Reason)
+	}
+	return fmt.Sprintf("playstore: %s (status %d)", e.Kind, e.StatusCode)
+}
+
+// Unwrap exposes the underlying error returned by the androidpublisher API.
+func (e *APIError) Unwrap() error {
+	return e.err
+}
+
+// Is reports whether target is an *APIError of the same Kind, so sentinels
+// below can be compared with errors.Is regardless of their StatusCode/Reason.
+func (e *APIError) Is(target error) bool {
+	t, ok := target.(*APIError)
+	if !ok {
+		return false
+	}
+	return e.Kind == t.Kind
+}
+
+// Sentinel errors returned (wrapped in an *APIError) by VerifyProduct,
+// VerifySubscription and the other Client methods that call androidpublisher.
+// Use errors.Is to test for them.
+var (
+	ErrTokenExpired     = &APIError{Kind: "token_expired"}
+	ErrPurchaseNotFound = &APIError{Kind: "purchase_not_found"}
+	ErrProductNotOwned  = &APIError{Kind: "product_not_owned"}
+	ErrQuotaExceeded    = &APIError{Kind: "quota_exceeded"}
+	ErrRateLimited      = &APIError{Kind: "rate_limited"}
+	ErrInvalidPackage   = &APIError{Kind: "invalid_package"}
+	ErrUnauthenticated  = &APIError{Kind: "unauthenticated"}
+	ErrPermissionDenied = &APIError{Kind: "permission_denied"}
+)
+
+// mapAPIError translates an error returned by androidpublisher into one of
+// the sentinels above when it recognizes the underlying *googleapi.Error. Any
+// other error, including nil, is returned unchanged.
+func mapAPIError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var gerr *googleapi.Error
+	if !errors.As(err, &gerr) {
+		return err
+	}
+
+	reason := ""
+	if len(gerr.Errors) > 0 {
+		reason = gerr.Errors[0].Reason
+	}
+
+	sentinel := classifyAPIError(gerr.Code, reason)
+	if sentinel == nil {
+		return err
+	}
+
+	return &APIError{
+		Kind:       sentinel.Kind,
+		StatusCode: gerr.Code,
+		Reason:     reason,
+		err:        err,
+	}
+}
+
+func classifyAPIError(statusCode int, reason string) *APIError {
+	switch reason {
+	case "productNotOwned":
+		return ErrProductNotOwned
+	case "quotaExceeded":
+		return ErrQuotaExceeded
+	case "purchaseTokenExpired", "expired":
+		return ErrTokenExpired
+	case "permissionDenied", "forbidden":
+		return ErrPermissionDenied
+	case "invalidPackageName", "applicationNotFound":
+		return ErrInvalidPackage
+	}
+
+	switch statusCode {
+	case 401:
+		return ErrUnauthenticated
+	case 403:
+		return ErrPermissionDenied
+	case 404:
+		return ErrPurchaseNotFound
+	case 429:
+		return ErrRateLimited
+	}
+
+	return nil
+}
+
+// isRetryable reports whether err represents a condition the retry policy
+// should retry: rate limiting, quota exhaustion, a server-side failure, or a
+// token-refresh failure from the oauth2 transport.
+func isRetryable(err error) bool {
+	err = mapAPIError(err)
+	if errors.Is(err, ErrRateLimited) || errors.Is(err, ErrQuotaExceeded) {
+		return true
+	}
+
+	var gerr *googleapi.Error
+	if errors.As(err, &gerr) {
+		return gerr.Code == 429 || gerr.Code >= 500
+	}
+
+	var oerr *oauth2.RetrieveError
+	if errors.As(err, &oerr) {
+		if oerr.Response == nil {
+			return true
+		}
+		return oerr.Response.StatusCode == 429 || oerr.Response.StatusCode >= 500
+	}
+
+	return false
+}
@@ -0,0 +1,72 @@
+package playstore
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	androidpublisher "google.golang.org/api/androidpublisher/v3"
+)
+
+// clientOptions accumulates the settings Option funcs populate.
+type clientOptions struct {
+	httpClient *http.Client
+	retry      RetryConfig
+	cache      Cache
+}
+
+// Option configures a Client built by NewWithOptions.
+type Option func(*clientOptions)
+
+// WithHTTPClient sets the http.Client used to fetch OAuth tokens and make
+// androidpublisher requests, in place of the 10 second timeout default.
+func WithHTTPClient(cli *http.Client) Option {
+	return func(o *clientOptions) {
+		o.httpClient = cli
+	}
+}
+
+// WithRetryConfig overrides the retry policy applied to androidpublisher
+// calls, in place of DefaultRetryConfig.
+func WithRetryConfig(cfg RetryConfig) Option {
+	return func(o *clientOptions) {
+		o.retry = cfg
+	}
+}
+
+// WithCache enables caching of VerifyProduct/VerifySubscription results, as
+// SetCache does.
+func WithCache(cache Cache) Option {
+	return func(o *clientOptions) {
+		o.cache = cache
+	}
+}
+
+// NewWithOptions returns a Client configured with the given Options, letting
+// callers plug in a custom http.Client, retry policy, and cache together.
+// Unlike New and NewWithClient, the default retry policy is
+// DefaultRetryConfig rather than no retrying at all.
+func NewWithOptions(jsonKey []byte, opts ...Option) (*Client, error) {
+	options := clientOptions{
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		retry:      DefaultRetryConfig(),
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, options.httpClient)
+
+	conf, err := google.JWTConfigFromJSON(jsonKey, androidpublisher.AndroidpublisherScope)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		httpCli: conf.Client(ctx),
+		cache:   options.cache,
+		retry:   options.retry,
+	}, nil
+}
@@ -0,0 +1,121 @@
+package playstore
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	"google.golang.org/api/googleapi"
+)
+
+// RetryConfig controls how Client retries androidpublisher calls that fail
+// with a retryable error (HTTP 429/5xx, quota exhaustion, or a token-refresh
+// failure).
+type RetryConfig struct {
+	// MaxAttempts is the total number of attempts, including the first.
+	// MaxAttempts <= 1 disables retrying.
+	MaxAttempts int
+
+	// InitialBackoff is the delay before the first retry.
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the delay between retries; backoff doubles on each
+	// attempt up to this ceiling. A MaxBackoff of zero keeps every delay at
+	// InitialBackoff.
+	MaxBackoff time.Duration
+
+	// Jitter is the fraction (0-1) of the computed backoff to randomize, to
+	// avoid retry storms across many clients.
+	Jitter float64
+}
+
+// DefaultRetryConfig returns the retry policy used by NewWithOptions unless
+// overridden with WithRetryConfig.
+func DefaultRetryConfig() RetryConfig {
+	return RetryConfig{
+		MaxAttempts:    3,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     10 * time.Second,
+		Jitter:         0.2,
+	}
+}
+
+// noRetryConfig is used by Client values constructed without
+// NewWithOptions, preserving their historical fail-fast behavior.
+var noRetryConfig = RetryConfig{MaxAttempts: 1}
+
+// withRetry runs fn, retrying according to cfg when fn's error is retryable.
+// It honors a Retry-After header on the underlying googleapi.Error, when
+// present, in place of the computed backoff.
+func withRetry(ctx context.Context, cfg RetryConfig, fn func() error) error {
+	maxAttempts := cfg.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	backoff := cfg.InitialBackoff
+	if backoff <= 0 {
+		backoff = DefaultRetryConfig().InitialBackoff
+	}
+
+	var err error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		err = fn()
+		if err == nil {
+			return nil
+		}
+
+		if attempt == maxAttempts || !isRetryable(err) {
+			return err
+		}
+
+		wait := retryAfter(err)
+		if wait <= 0 {
+			wait = jitter(backoff, cfg.Jitter)
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		if cfg.MaxBackoff > 0 && backoff < cfg.MaxBackoff {
+			backoff *= 2
+			if backoff > cfg.MaxBackoff {
+				backoff = cfg.MaxBackoff
+			}
+		}
+	}
+
+	return err
+}
+
+// retryAfter extracts the Retry-After delay from a rate-limited response, if
+// the API provided one.
+func retryAfter(err error) time.Duration {
+	var gerr *googleapi.Error
+	if !errors.As(err, &gerr) || gerr.Header == nil {
+		return 0
+	}
+
+	value := gerr.Header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+
+	if seconds, parseErr := time.ParseDuration(value + "s"); parseErr == nil {
+		return seconds
+	}
+
+	return 0
+}
+
+func jitter(d time.Duration, fraction float64) time.Duration {
+	if fraction <= 0 {
+		return d
+	}
+	delta := float64(d) * fraction
+	return d - time.Duration(delta) + time.Duration(rand.Float64()*2*delta)
+}
@@ -0,0 +1,320 @@
+package playstore
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"google.golang.org/api/androidpublisher/v3"
+	"google.golang.org/api/idtoken"
+)
+
+// Real-Time Developer Notification types, as documented at
+// https://developer.android.com/google/play/billing/rtdn-reference
+const (
+	NotificationTypeSubscriptionRecovered            = 1
+	NotificationTypeSubscriptionRenewed              = 2
+	NotificationTypeSubscriptionCanceled             = 3
+	NotificationTypeSubscriptionPurchased            = 4
+	NotificationTypeSubscriptionOnHold               = 5
+	NotificationTypeSubscriptionInGracePeriod        = 6
+	NotificationTypeSubscriptionRestarted            = 7
+	NotificationTypeSubscriptionPriceChangeConfirmed = 8
+	NotificationTypeSubscriptionDeferred             = 9
+	NotificationTypeSubscriptionPaused               = 10
+	NotificationTypeSubscriptionPauseScheduleChanged = 11
+	NotificationTypeSubscriptionRevoked              = 12
+	NotificationTypeSubscriptionExpired              = 13
+)
+
+// SubscriptionNotification is the `subscriptionNotification` one-of field of a
+// DeveloperNotification.
+type SubscriptionNotification struct {
+	Version          string `json:"version"`
+	NotificationType int    `json:"notificationType"`
+	PurchaseToken    string `json:"purchaseToken"`
+	SubscriptionID   string `json:"subscriptionId"`
+}
+
+// OneTimeProductNotification is the `oneTimeProductNotification` one-of field
+// of a DeveloperNotification.
+type OneTimeProductNotification struct {
+	Version          string `json:"version"`
+	NotificationType int    `json:"notificationType"`
+	PurchaseToken    string `json:"purchaseToken"`
+	SKU              string `json:"sku"`
+}
+
+// VoidedPurchaseNotification is the `voidedPurchaseNotification` one-of field
+// of a DeveloperNotification.
+type VoidedPurchaseNotification struct {
+	PurchaseToken string `json:"purchaseToken"`
+	OrderID       string `json:"orderId"`
+	ProductType   int    `json:"productType"`
+	RefundType    int    `json:"refundType"`
+}
+
+// TestNotification is the `testNotification` one-of field of a
+// DeveloperNotification, sent when a developer presses the "Send test
+// notification" button in the Play Console.
+type TestNotification struct {
+	Version string `json:"version"`
+}
+
+// DeveloperNotification is the envelope Google Play publishes to the
+// configured Pub/Sub topic for Real-Time Developer Notifications (RTDN).
+// Exactly one of the *Notification fields is populated, depending on the
+// event that occurred.
+type DeveloperNotification struct {
+	Version         string `json:"version"`
+	PackageName     string `json:"packageName"`
+	EventTimeMillis string `json:"eventTimeMillis"`
+
+	SubscriptionNotification   *SubscriptionNotification   `json:"subscriptionNotification,omitempty"`
+	OneTimeProductNotification *OneTimeProductNotification `json:"oneTimeProductNotification,omitempty"`
+	VoidedPurchaseNotification *VoidedPurchaseNotification `json:"voidedPurchaseNotification,omitempty"`
+	TestNotification           *TestNotification           `json:"testNotification,omitempty"`
+}
+
+// pubSubPushRequest models the envelope Cloud Pub/Sub wraps around a push
+// delivery: https://cloud.google.com/pubsub/docs/push#receiving_messages
+type pubSubPushRequest struct {
+	Message struct {
+		Data        string `json:"data"`
+		MessageID   string `json:"messageId"`
+		PublishTime string `json:"publishTime"`
+	} `json:"message"`
+	Subscription string `json:"subscription"`
+}
+
+// ParsePubSubPush decodes a Cloud Pub/Sub push request body into the
+// DeveloperNotification it carries. It does not verify the request's
+// authenticity; use NewRTDNHandler for an http.Handler that does.
+func ParsePubSubPush(r *http.Request) (*DeveloperNotification, error) {
+	var push pubSubPushRequest
+	if err := json.NewDecoder(r.Body).Decode(&push); err != nil {
+		return nil, fmt.Errorf("playstore: failed to decode pub/sub push body: %w", err)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(push.Message.Data)
+	if err != nil {
+		return nil, fmt.Errorf("playstore: failed to decode pub/sub message data: %w", err)
+	}
+
+	var notification DeveloperNotification
+	if err := json.Unmarshal(data, &notification); err != nil {
+		return nil, fmt.Errorf("playstore: failed to decode developer notification: %w", err)
+	}
+
+	return &notification, nil
+}
+
+// SubscriptionEventHandler handles a subscription-related RTDN event. purchase
+// is the SubscriptionPurchase resolved by automatically calling
+// VerifySubscription with the notification's purchase token, so the handler
+// gets both the event and its current state in one callback. For terminal
+// events (canceled, expired, revoked) the purchase token often no longer
+// resolves; resolution there is best-effort, so purchase may be nil with
+// resolveErr set to the VerifySubscription failure instead of the handler
+// never running.
+type SubscriptionEventHandler func(ctx context.Context, notification *DeveloperNotification, event *SubscriptionNotification, purchase *androidpublisher.SubscriptionPurchase, resolveErr error) error
+
+// VoidedPurchaseHandler handles a voided purchase RTDN event.
+type VoidedPurchaseHandler func(ctx context.Context, notification *DeveloperNotification, event *VoidedPurchaseNotification) error
+
+// OneTimeProductHandler handles a one-time product RTDN event.
+type OneTimeProductHandler func(ctx context.Context, notification *DeveloperNotification, event *OneTimeProductNotification) error
+
+// RTDNConfig configures an RTDN http.Handler built by NewRTDNHandler.
+type RTDNConfig struct {
+	// Client is used to resolve subscription events into their current
+	// SubscriptionPurchase state via VerifySubscription. Required.
+	Client *Client
+
+	// Audience is the expected "aud" claim of the Pub/Sub push OIDC token,
+	// normally the push endpoint URL configured on the subscription.
+	Audience string
+
+	// ServiceAccountEmail is the expected "email" claim of the OIDC token,
+	// i.e. the service account Pub/Sub was configured to authenticate as.
+	ServiceAccountEmail string
+
+	// One callback per notificationType code. All are optional; nil
+	// callbacks are simply skipped.
+	OnSubscriptionRecovered            SubscriptionEventHandler
+	OnSubscriptionRenewed              SubscriptionEventHandler
+	OnSubscriptionCanceled             SubscriptionEventHandler
+	OnSubscriptionPurchased            SubscriptionEventHandler
+	OnSubscriptionOnHold               SubscriptionEventHandler
+	OnSubscriptionInGracePeriod        SubscriptionEventHandler
+	OnSubscriptionRestarted            SubscriptionEventHandler
+	OnSubscriptionPriceChangeConfirmed SubscriptionEventHandler
+	OnSubscriptionDeferred             SubscriptionEventHandler
+	OnSubscriptionPaused               SubscriptionEventHandler
+	OnSubscriptionPauseScheduleChanged SubscriptionEventHandler
+	OnSubscriptionRevoked              SubscriptionEventHandler
+	OnSubscriptionExpired              SubscriptionEventHandler
+
+	OnVoidedPurchase         VoidedPurchaseHandler
+	OnOneTimeProductPurchase OneTimeProductHandler
+	OnTestNotification       func(ctx context.Context, notification *DeveloperNotification) error
+}
+
+func (cfg RTDNConfig) subscriptionHandler(notificationType int) SubscriptionEventHandler {
+	switch notificationType {
+	case NotificationTypeSubscriptionRecovered:
+		return cfg.OnSubscriptionRecovered
+	case NotificationTypeSubscriptionRenewed:
+		return cfg.OnSubscriptionRenewed
+	case NotificationTypeSubscriptionCanceled:
+		return cfg.OnSubscriptionCanceled
+	case NotificationTypeSubscriptionPurchased:
+		return cfg.OnSubscriptionPurchased
+	case NotificationTypeSubscriptionOnHold:
+		return cfg.OnSubscriptionOnHold
+	case NotificationTypeSubscriptionInGracePeriod:
+		return cfg.OnSubscriptionInGracePeriod
+	case NotificationTypeSubscriptionRestarted:
+		return cfg.OnSubscriptionRestarted
+	case NotificationTypeSubscriptionPriceChangeConfirmed:
+		return cfg.OnSubscriptionPriceChangeConfirmed
+	case NotificationTypeSubscriptionDeferred:
+		return cfg.OnSubscriptionDeferred
+	case NotificationTypeSubscriptionPaused:
+		return cfg.OnSubscriptionPaused
+	case NotificationTypeSubscriptionPauseScheduleChanged:
+		return cfg.OnSubscriptionPauseScheduleChanged
+	case NotificationTypeSubscriptionRevoked:
+		return cfg.OnSubscriptionRevoked
+	case NotificationTypeSubscriptionExpired:
+		return cfg.OnSubscriptionExpired
+	default:
+		return nil
+	}
+}
+
+// NewRTDNHandler returns an http.Handler that ingests Google Play Real-Time
+// Developer Notifications delivered via a Cloud Pub/Sub push subscription. It
+// validates the push request's OIDC bearer token against cfg.Audience and
+// cfg.ServiceAccountEmail, parses the notification, resolves subscription
+// events against the Play API, dispatches to cfg's typed callbacks, and
+// finally invokes onEvent with the notification. onEvent is not called when
+// the request is rejected or dispatching the notification fails.
+func NewRTDNHandler(cfg RTDNConfig, onEvent func(ctx context.Context, notification *DeveloperNotification) error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		if err := validatePushToken(ctx, r, cfg.Audience, cfg.ServiceAccountEmail); err != nil {
+			http.Error(w, fmt.Sprintf("playstore: unauthorized push request: %v", err), http.StatusUnauthorized)
+			return
+		}
+
+		notification, err := ParsePubSubPush(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := dispatchNotification(ctx, cfg, notification); err != nil {
+			http.Error(w, fmt.Sprintf("playstore: failed to handle notification: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		if onEvent != nil {
+			if err := onEvent(ctx, notification); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// terminalSubscriptionNotificationTypes are notification types whose purchase
+// token commonly no longer resolves via VerifySubscription, so resolution
+// failures there must not fail the whole push.
+var terminalSubscriptionNotificationTypes = map[int]bool{
+	NotificationTypeSubscriptionCanceled: true,
+	NotificationTypeSubscriptionExpired:  true,
+	NotificationTypeSubscriptionRevoked:  true,
+}
+
+func dispatchNotification(ctx context.Context, cfg RTDNConfig, notification *DeveloperNotification) error {
+	switch {
+	case notification.SubscriptionNotification != nil:
+		event := notification.SubscriptionNotification
+		handler := cfg.subscriptionHandler(event.NotificationType)
+		if handler == nil {
+			return nil
+		}
+
+		var purchase *androidpublisher.SubscriptionPurchase
+		var resolveErr error
+		if cfg.Client != nil {
+			p, err := cfg.Client.VerifySubscription(ctx, notification.PackageName, event.SubscriptionID, event.PurchaseToken)
+			if err != nil {
+				if !terminalSubscriptionNotificationTypes[event.NotificationType] {
+					return fmt.Errorf("playstore: failed to resolve subscription state: %w", err)
+				}
+				resolveErr = fmt.Errorf("playstore: failed to resolve subscription state: %w", err)
+			} else {
+				purchase = p
+			}
+		}
+
+		return handler(ctx, notification, event, purchase, resolveErr)
+
+	case notification.VoidedPurchaseNotification != nil:
+		if cfg.OnVoidedPurchase == nil {
+			return nil
+		}
+		return cfg.OnVoidedPurchase(ctx, notification, notification.VoidedPurchaseNotification)
+
+	case notification.OneTimeProductNotification != nil:
+		if cfg.OnOneTimeProductPurchase == nil {
+			return nil
+		}
+		return cfg.OnOneTimeProductPurchase(ctx, notification, notification.OneTimeProductNotification)
+
+	case notification.TestNotification != nil:
+		if cfg.OnTestNotification == nil {
+			return nil
+		}
+		return cfg.OnTestNotification(ctx, notification)
+	}
+
+	return nil
+}
+
+// validatePushToken verifies the OIDC bearer token Cloud Pub/Sub attaches to
+// push requests, checking both the audience and the issuing service account.
+func validatePushToken(ctx context.Context, r *http.Request, audience string, serviceAccountEmail string) error {
+	authHeader := r.Header.Get("Authorization")
+	token := strings.TrimPrefix(authHeader, "Bearer ")
+	if token == "" || token == authHeader {
+		return fmt.Errorf("missing bearer token")
+	}
+
+	payload, err := idtoken.Validate(ctx, token, audience)
+	if err != nil {
+		return fmt.Errorf("invalid token: %w", err)
+	}
+
+	if serviceAccountEmail != "" {
+		emailVerified, _ := payload.Claims["email_verified"].(bool)
+		if !emailVerified {
+			return fmt.Errorf("token email claim is not verified")
+		}
+
+		email, _ := payload.Claims["email"].(string)
+		if email != serviceAccountEmail {
+			return fmt.Errorf("unexpected token issuer %q", email)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package playstore
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+)
+
+// ErrMalformedSignature is returned when a public key passed to
+// ParsePublicKey, or a signature passed to VerifySignatureWithOptions, can't
+// be decoded or parsed, and when VerifySignatureWithOptions is given no
+// public keys or an unsupported hash.
+var ErrMalformedSignature = errors.New("playstore: malformed public key or signature")
+
+// ErrSignatureMismatch is returned when the public key, receipt and signature
+// were all well-formed, but none of the configured keys verified the
+// signature.
+var ErrSignatureMismatch = errors.New("playstore: signature did not verify")
+
+// VerifyOptions configures VerifySignatureWithOptions.
+type VerifyOptions struct {
+	// PublicKeys are tried in order; the first one that verifies the
+	// signature wins. Supplying more than one supports rotating the
+	// in-app billing public key without rejecting receipts signed under the
+	// previous key.
+	PublicKeys []*rsa.PublicKey
+
+	// Hash selects the digest algorithm the signature was produced with.
+	// Play Billing Library v2 and earlier sign with crypto.SHA1; Billing
+	// Library 5+ and Play Integrity use crypto.SHA256.
+	Hash crypto.Hash
+}
+
+// ParsePublicKey decodes and parses the base64-encoded public key Google
+// Play shows for an app's in-app billing configuration, so callers can parse
+// it once and reuse it across VerifySignatureWithOptions calls.
+func ParsePublicKey(base64EncodedPublicKey string) (*rsa.PublicKey, error) {
+	decoded, err := base64.StdEncoding.DecodeString(base64EncodedPublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to decode public key: %v", ErrMalformedSignature, err)
+	}
+
+	parsed, err := x509.ParsePKIXPublicKey(decoded)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to parse public key: %v", ErrMalformedSignature, err)
+	}
+
+	publicKey, ok := parsed.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: public key is %T, not an RSA key", ErrMalformedSignature, parsed)
+	}
+
+	return publicKey, nil
+}
+
+// VerifySignatureWithOptions verifies an in-app billing receipt's signature
+// against one or more public keys, using the hash algorithm opts.Hash
+// specifies. It returns ErrMalformedSignature (wrapped with details) when no
+// keys are supplied, opts.Hash is unsupported, or the signature isn't valid
+// base64, and ErrSignatureMismatch when no key verified the signature. Nil
+// entries in opts.PublicKeys are skipped.
+func VerifySignatureWithOptions(receipt []byte, signature string, opts VerifyOptions) (bool, error) {
+	if len(opts.PublicKeys) == 0 {
+		return false, fmt.Errorf("%w: no public keys supplied", ErrMalformedSignature)
+	}
+
+	hasher, err := newHasher(opts.Hash)
+	if err != nil {
+		return false, err
+	}
+	hasher.Write(receipt)
+	hashedReceipt := hasher.Sum(nil)
+
+	decodedSignature, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return false, fmt.Errorf("%w: failed to decode signature: %v", ErrMalformedSignature, err)
+	}
+
+	for _, publicKey := range opts.PublicKeys {
+		if publicKey == nil {
+			continue
+		}
+		if err := rsa.VerifyPKCS1v15(publicKey, opts.Hash, hashedReceipt, decodedSignature); err == nil {
+			return true, nil
+		}
+	}
+
+	return false, ErrSignatureMismatch
+}
+
+func newHasher(h crypto.Hash) (hash.Hash, error) {
+	switch h {
+	case crypto.SHA1:
+		return sha1.New(), nil
+	case crypto.SHA256:
+		return sha256.New(), nil
+	default:
+		return nil, fmt.Errorf("%w: unsupported hash %v", ErrMalformedSignature, h)
+	}
+}
@@ -0,0 +1,145 @@
+package playstore
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func mustGenerateKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey: %v", err)
+	}
+	return key
+}
+
+func mustSign(t *testing.T, key *rsa.PrivateKey, h crypto.Hash, receipt []byte) string {
+	t.Helper()
+
+	var hashed []byte
+	switch h {
+	case crypto.SHA1:
+		sum := sha1.Sum(receipt)
+		hashed = sum[:]
+	case crypto.SHA256:
+		sum := sha256.Sum256(receipt)
+		hashed = sum[:]
+	default:
+		t.Fatalf("unsupported hash in test: %v", h)
+	}
+
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, h, hashed)
+	if err != nil {
+		t.Fatalf("rsa.SignPKCS1v15: %v", err)
+	}
+	return base64.StdEncoding.EncodeToString(signature)
+}
+
+func TestVerifySignatureWithOptions(t *testing.T) {
+	sha1Key := mustGenerateKey(t)
+	sha256Key := mustGenerateKey(t)
+	rotatedOldKey := mustGenerateKey(t)
+	rotatedNewKey := mustGenerateKey(t)
+	otherKey := mustGenerateKey(t)
+
+	receipt := []byte(`{"orderId":"GPA.1234-5678-9012-34567"}`)
+
+	tests := []struct {
+		name      string
+		receipt   []byte
+		signature string
+		opts      VerifyOptions
+		wantOK    bool
+		wantErr   error
+	}{
+		{
+			name:      "valid SHA-1 receipt",
+			receipt:   receipt,
+			signature: mustSign(t, sha1Key, crypto.SHA1, receipt),
+			opts:      VerifyOptions{PublicKeys: []*rsa.PublicKey{&sha1Key.PublicKey}, Hash: crypto.SHA1},
+			wantOK:    true,
+		},
+		{
+			name:      "valid SHA-256 receipt",
+			receipt:   receipt,
+			signature: mustSign(t, sha256Key, crypto.SHA256, receipt),
+			opts:      VerifyOptions{PublicKeys: []*rsa.PublicKey{&sha256Key.PublicKey}, Hash: crypto.SHA256},
+			wantOK:    true,
+		},
+		{
+			name:      "rotated key hit on second key",
+			receipt:   receipt,
+			signature: mustSign(t, rotatedNewKey, crypto.SHA256, receipt),
+			opts: VerifyOptions{
+				PublicKeys: []*rsa.PublicKey{&rotatedOldKey.PublicKey, &rotatedNewKey.PublicKey},
+				Hash:       crypto.SHA256,
+			},
+			wantOK: true,
+		},
+		{
+			name:      "malformed signature",
+			receipt:   receipt,
+			signature: "not-valid-base64!!",
+			opts:      VerifyOptions{PublicKeys: []*rsa.PublicKey{&sha256Key.PublicKey}, Hash: crypto.SHA256},
+			wantErr:   ErrMalformedSignature,
+		},
+		{
+			name:      "good key, bad signature",
+			receipt:   receipt,
+			signature: mustSign(t, otherKey, crypto.SHA256, receipt),
+			opts:      VerifyOptions{PublicKeys: []*rsa.PublicKey{&sha256Key.PublicKey}, Hash: crypto.SHA256},
+			wantErr:   ErrSignatureMismatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, err := VerifySignatureWithOptions(tt.receipt, tt.signature, tt.opts)
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("VerifySignatureWithOptions() err = %v, want %v", err, tt.wantErr)
+				}
+				if ok {
+					t.Fatalf("VerifySignatureWithOptions() ok = true, want false")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("VerifySignatureWithOptions() unexpected err = %v", err)
+			}
+			if ok != tt.wantOK {
+				t.Fatalf("VerifySignatureWithOptions() ok = %v, want %v", ok, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestParsePublicKeyMalformed(t *testing.T) {
+	if _, err := ParsePublicKey("not-valid-base64!!"); !errors.Is(err, ErrMalformedSignature) {
+		t.Fatalf("ParsePublicKey() err = %v, want %v", err, ErrMalformedSignature)
+	}
+
+	key := mustGenerateKey(t)
+	encodedKey, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("x509.MarshalPKIXPublicKey: %v", err)
+	}
+
+	parsed, err := ParsePublicKey(base64.StdEncoding.EncodeToString(encodedKey))
+	if err != nil {
+		t.Fatalf("ParsePublicKey() unexpected err = %v", err)
+	}
+	if parsed.N.Cmp(key.PublicKey.N) != 0 {
+		t.Fatalf("ParsePublicKey() returned a different key than was encoded")
+	}
+}
@@ -0,0 +1,174 @@
+package playstore
+
+import (
+	"context"
+
+	"google.golang.org/api/androidpublisher/v3"
+	"google.golang.org/api/iterator"
+)
+
+// GetSubscriptionV2 fetches a subscription purchase using the
+// purchases.subscriptionsv2 API. Unlike VerifySubscription, the returned
+// SubscriptionPurchaseV2 exposes line items, subscription state, region code,
+// and paused/canceled/offer details for base-plan-and-offer subscriptions
+// that aren't representable in the v1 SubscriptionPurchase type.
+func (c *Client) GetSubscriptionV2(
+	ctx context.Context,
+	packageName string,
+	token string,
+) (*androidpublisher.SubscriptionPurchaseV2, error) {
+	var result *androidpublisher.SubscriptionPurchaseV2
+	err := withRetry(ctx, c.retry, func() error {
+		service, err := androidpublisher.New(c.httpCli)
+		if err != nil {
+			return err
+		}
+
+		ps := androidpublisher.NewPurchasesSubscriptionsV2Service(service)
+		result, err = ps.Get(packageName, token).Context(ctx).Do()
+		return err
+	})
+	if err != nil {
+		return nil, mapAPIError(err)
+	}
+
+	return result, nil
+}
+
+// RevokeSubscriptionV2 revokes a subscription purchase using the
+// purchases.subscriptionsv2 API, optionally refunding only a subset of a
+// multi-line subscription's products via req.RevocationContext.
+func (c *Client) RevokeSubscriptionV2(
+	ctx context.Context,
+	packageName string,
+	token string,
+	req *androidpublisher.RevokeSubscriptionPurchaseRequest,
+) error {
+	err := withRetry(ctx, c.retry, func() error {
+		service, err := androidpublisher.New(c.httpCli)
+		if err != nil {
+			return err
+		}
+
+		ps := androidpublisher.NewPurchasesSubscriptionsV2Service(service)
+		_, err = ps.Revoke(packageName, token, req).Context(ctx).Do()
+		return err
+	})
+
+	return mapAPIError(err)
+}
+
+// VoidedPurchasesListOptions configures ListVoidedPurchases. All fields are
+// optional; the zero value lists the most recent voided purchases with the
+// API's default page size.
+type VoidedPurchasesListOptions struct {
+	// StartTime and EndTime bound the query to voided purchases whose
+	// voidedTimeMillis falls within the given range, in Unix millis.
+	StartTime int64
+	EndTime   int64
+
+	// MaxResults caps the number of voided purchases fetched per page.
+	MaxResults int64
+
+	// Type selects which voided purchases are listed: 0 for one-time
+	// products only, 1 for both one-time products and subscriptions. A zero
+	// Type is not sent, so the API's default (one-time products only)
+	// applies.
+	Type int64
+}
+
+// VoidedPurchasesIterator lists voided purchases a page at a time,
+// transparently following the API's pagination token.
+type VoidedPurchasesIterator struct {
+	ctx         context.Context
+	service     *androidpublisher.PurchasesVoidedpurchasesService
+	packageName string
+	opts        VoidedPurchasesListOptions
+	retry       RetryConfig
+
+	buf       []*androidpublisher.VoidedPurchase
+	nextToken string
+	exhausted bool
+}
+
+// ListVoidedPurchases returns an iterator over voided purchases for
+// packageName, most recently voided first.
+func (c *Client) ListVoidedPurchases(
+	ctx context.Context,
+	packageName string,
+	opts VoidedPurchasesListOptions,
+) (*VoidedPurchasesIterator, error) {
+	service, err := androidpublisher.New(c.httpCli)
+	if err != nil {
+		return nil, err
+	}
+
+	return &VoidedPurchasesIterator{
+		ctx:         ctx,
+		service:     androidpublisher.NewPurchasesVoidedpurchasesService(service),
+		packageName: packageName,
+		opts:        opts,
+		retry:       c.retry,
+	}, nil
+}
+
+// Next returns the next voided purchase, fetching another page from the API
+// when the current one is exhausted. It returns iterator.Done once there are
+// no more results.
+func (it *VoidedPurchasesIterator) Next() (*androidpublisher.VoidedPurchase, error) {
+	if len(it.buf) == 0 {
+		if it.exhausted {
+			return nil, iterator.Done
+		}
+		if err := it.fetch(); err != nil {
+			return nil, err
+		}
+		if len(it.buf) == 0 {
+			return nil, iterator.Done
+		}
+	}
+
+	purchase := it.buf[0]
+	it.buf = it.buf[1:]
+
+	return purchase, nil
+}
+
+func (it *VoidedPurchasesIterator) fetch() error {
+	call := it.service.List(it.packageName).Context(it.ctx)
+
+	if it.opts.StartTime != 0 {
+		call = call.StartTime(it.opts.StartTime)
+	}
+	if it.opts.EndTime != 0 {
+		call = call.EndTime(it.opts.EndTime)
+	}
+	if it.opts.MaxResults != 0 {
+		call = call.MaxResults(it.opts.MaxResults)
+	}
+	if it.opts.Type != 0 {
+		call = call.Type(it.opts.Type)
+	}
+	if it.nextToken != "" {
+		call = call.Token(it.nextToken)
+	}
+
+	var resp *androidpublisher.VoidedPurchasesListResponse
+	err := withRetry(it.ctx, it.retry, func() error {
+		var err error
+		resp, err = call.Do()
+		return err
+	})
+	if err != nil {
+		return mapAPIError(err)
+	}
+
+	it.buf = resp.VoidedPurchases
+	if resp.TokenPagination != nil && resp.TokenPagination.NextPageToken != "" {
+		it.nextToken = resp.TokenPagination.NextPageToken
+	} else {
+		it.exhausted = true
+	}
+
+	return nil
+}
@@ -4,15 +4,14 @@ import (
 	"context"
 	"crypto"
 	"crypto/rsa"
-	"crypto/sha1"
-	"crypto/x509"
-	"encoding/base64"
-	"fmt"
+	"encoding/json"
+	"errors"
 	"net/http"
 	"time"
 
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
+	"golang.org/x/sync/singleflight"
 	androidpublisher "google.golang.org/api/androidpublisher/v3"
 )
 
@@ -25,15 +24,38 @@ type IABProduct interface {
 // The IABSubscription type is an interface  for subscription service
 type IABSubscription interface {
 	AcknowledgeSubscription(context.Context, string, string, string, *androidpublisher.SubscriptionPurchasesAcknowledgeRequest) error
+
+	// VerifySubscription uses the purchases.subscriptions (v1) API.
+	//
+	// Deprecated: base plans, offers and multi-line subscriptions aren't
+	// representable in the v1 SubscriptionPurchase type. Prefer
+	// GetSubscriptionV2. Kept as a fallback for callers still depending on
+	// the v1 response shape.
 	VerifySubscription(context.Context, string, string, string) (*androidpublisher.SubscriptionPurchase, error)
 	CancelSubscription(context.Context, string, string, string) error
 	RefundSubscription(context.Context, string, string, string) error
 	RevokeSubscription(context.Context, string, string, string) error
+
+	GetSubscriptionV2(context.Context, string, string) (*androidpublisher.SubscriptionPurchaseV2, error)
+	RevokeSubscriptionV2(context.Context, string, string, *androidpublisher.RevokeSubscriptionPurchaseRequest) error
+	ListVoidedPurchases(context.Context, string, VoidedPurchasesListOptions) (*VoidedPurchasesIterator, error)
 }
 
 // The Client type implements VerifySubscription method
 type Client struct {
 	httpCli *http.Client
+
+	// cache, when set via SetCache, serves repeated VerifyProduct and
+	// VerifySubscription calls without hitting the Play API, and coalesces
+	// concurrent in-flight calls for the same key.
+	cache Cache
+	group singleflight.Group
+
+	// retry controls automatic retrying of failed androidpublisher calls.
+	// Its zero value disables retrying, matching the historical behavior of
+	// New and NewWithClient; NewWithOptions defaults it to
+	// DefaultRetryConfig.
+	retry RetryConfig
 }
 
 // New returns http client which includes the credentials to access androidpublisher API.
@@ -43,8 +65,11 @@ func New(jsonKey []byte) (*Client, error) {
 	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{Timeout: 10 * time.Second})
 
 	conf, err := google.JWTConfigFromJSON(jsonKey, androidpublisher.AndroidpublisherScope)
+	if err != nil {
+		return nil, err
+	}
 
-	return &Client{conf.Client(ctx)}, err
+	return &Client{httpCli: conf.Client(ctx), retry: noRetryConfig}, nil
 }
 
 // NewWithClient returns http client which includes the custom http client.
@@ -56,7 +81,7 @@ func NewWithClient(jsonKey []byte, cli *http.Client) (*Client, error) {
 		return nil, err
 	}
 
-	return &Client{conf.Client(ctx)}, err
+	return &Client{httpCli: conf.Client(ctx), retry: noRetryConfig}, nil
 }
 
 // AcknowledgeSubscription acknowledges a subscription purchase.
@@ -67,15 +92,17 @@ func (c *Client) AcknowledgeSubscription(
 	token string,
 	req *androidpublisher.SubscriptionPurchasesAcknowledgeRequest,
 ) error {
-	service, err := androidpublisher.New(c.httpCli)
-	if err != nil {
-		return err
-	}
+	err := withRetry(ctx, c.retry, func() error {
+		service, err := androidpublisher.New(c.httpCli)
+		if err != nil {
+			return err
+		}
 
-	ps := androidpublisher.NewPurchasesSubscriptionsService(service)
-	err = ps.Acknowledge(packageName, subscriptionID, token, req).Context(ctx).Do()
+		ps := androidpublisher.NewPurchasesSubscriptionsService(service)
+		return ps.Acknowledge(packageName, subscriptionID, token, req).Context(ctx).Do()
+	})
 
-	return err
+	return mapAPIError(err)
 }
 
 // VerifySubscription verifies subscription status
@@ -85,15 +112,55 @@ func (c *Client) VerifySubscription(
 	subscriptionID string,
 	token string,
 ) (*androidpublisher.SubscriptionPurchase, error) {
-	service, err := androidpublisher.New(c.httpCli)
+	key := cacheKey(packageName, subscriptionID, token)
+
+	if c.cache != nil {
+		if cached, ok := c.cache.Get(key); ok {
+			var result androidpublisher.SubscriptionPurchase
+			if err := json.Unmarshal(cached, &result); err == nil {
+				return &result, nil
+			}
+		}
+	}
+
+	v, err, _ := c.group.Do(key, func() (interface{}, error) {
+		var result *androidpublisher.SubscriptionPurchase
+		err := withRetry(ctx, c.retry, func() error {
+			service, err := androidpublisher.New(c.httpCli)
+			if err != nil {
+				return err
+			}
+
+			ps := androidpublisher.NewPurchasesSubscriptionsService(service)
+			result, err = ps.Get(packageName, subscriptionID, token).Context(ctx).Do()
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if c.cache != nil {
+			if encoded, err := json.Marshal(result); err == nil {
+				c.cache.Set(key, encoded, subscriptionCacheTTL(result))
+			}
+		}
+
+		return result, nil
+	})
 	if err != nil {
-		return nil, err
+		return nil, mapAPIError(err)
 	}
 
-	ps := androidpublisher.NewPurchasesSubscriptionsService(service)
-	result, err := ps.Get(packageName, subscriptionID, token).Context(ctx).Do()
+	return v.(*androidpublisher.SubscriptionPurchase), nil
+}
 
-	return result, err
+// subscriptionCacheTTL picks a short TTL for not-yet-acknowledged purchases,
+// since their state can change quickly, and a longer one once acknowledged.
+func subscriptionCacheTTL(purchase *androidpublisher.SubscriptionPurchase) time.Duration {
+	if purchase.AcknowledgementState == 1 {
+		return defaultAcknowledgedCacheTTL
+	}
+	return defaultUnacknowledgedCacheTTL
 }
 
 func (c *Client) AcknowledgeProduct(
@@ -103,15 +170,17 @@ func (c *Client) AcknowledgeProduct(
 	token string,
 	req *androidpublisher.ProductPurchasesAcknowledgeRequest,
 ) error {
-	service, err := androidpublisher.New(c.httpCli)
-	if err != nil {
-		return err
-	}
+	err := withRetry(ctx, c.retry, func() error {
+		service, err := androidpublisher.New(c.httpCli)
+		if err != nil {
+			return err
+		}
 
-	ps := androidpublisher.NewPurchasesProductsService(service)
-	err = ps.Acknowledge(packageName, subscriptionID, token, req).Context(ctx).Do()
+		ps := androidpublisher.NewPurchasesProductsService(service)
+		return ps.Acknowledge(packageName, subscriptionID, token, req).Context(ctx).Do()
+	})
 
-	return err
+	return mapAPIError(err)
 }
 
 // VerifyProduct verifies product status
@@ -121,88 +190,125 @@ func (c *Client) VerifyProduct(
 	productID string,
 	token string,
 ) (*androidpublisher.ProductPurchase, error) {
-	service, err := androidpublisher.New(c.httpCli)
+	key := cacheKey(packageName, productID, token)
+
+	if c.cache != nil {
+		if cached, ok := c.cache.Get(key); ok {
+			var result androidpublisher.ProductPurchase
+			if err := json.Unmarshal(cached, &result); err == nil {
+				return &result, nil
+			}
+		}
+	}
+
+	v, err, _ := c.group.Do(key, func() (interface{}, error) {
+		var result *androidpublisher.ProductPurchase
+		err := withRetry(ctx, c.retry, func() error {
+			service, err := androidpublisher.New(c.httpCli)
+			if err != nil {
+				return err
+			}
+
+			ps := androidpublisher.NewPurchasesProductsService(service)
+			result, err = ps.Get(packageName, productID, token).Context(ctx).Do()
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if c.cache != nil {
+			if encoded, err := json.Marshal(result); err == nil {
+				c.cache.Set(key, encoded, productCacheTTL(result))
+			}
+		}
+
+		return result, nil
+	})
 	if err != nil {
-		return nil, err
+		return nil, mapAPIError(err)
 	}
 
-	ps := androidpublisher.NewPurchasesProductsService(service)
-	result, err := ps.Get(packageName, productID, token).Context(ctx).Do()
+	return v.(*androidpublisher.ProductPurchase), nil
+}
 
-	return result, err
+// productCacheTTL picks a short TTL for not-yet-acknowledged purchases, since
+// their state can change quickly, and a longer one once acknowledged.
+func productCacheTTL(purchase *androidpublisher.ProductPurchase) time.Duration {
+	if purchase.AcknowledgementState == 1 {
+		return defaultAcknowledgedCacheTTL
+	}
+	return defaultUnacknowledgedCacheTTL
 }
 
 // CancelSubscription cancels a user's subscription purchase.
 func (c *Client) CancelSubscription(ctx context.Context, packageName string, subscriptionID string, token string) error {
-	service, err := androidpublisher.New(c.httpCli)
-	if err != nil {
-		return err
-	}
+	err := withRetry(ctx, c.retry, func() error {
+		service, err := androidpublisher.New(c.httpCli)
+		if err != nil {
+			return err
+		}
 
-	ps := androidpublisher.NewPurchasesSubscriptionsService(service)
-	err = ps.Cancel(packageName, subscriptionID, token).Context(ctx).Do()
+		ps := androidpublisher.NewPurchasesSubscriptionsService(service)
+		return ps.Cancel(packageName, subscriptionID, token).Context(ctx).Do()
+	})
 
-	return err
+	return mapAPIError(err)
 }
 
 // RefundSubscription refunds a user's subscription purchase, but the subscription remains valid
 // until its expiration time and it will continue to recur.
 func (c *Client) RefundSubscription(ctx context.Context, packageName string, subscriptionID string, token string) error {
-	service, err := androidpublisher.New(c.httpCli)
-	if err != nil {
-		return err
-	}
+	err := withRetry(ctx, c.retry, func() error {
+		service, err := androidpublisher.New(c.httpCli)
+		if err != nil {
+			return err
+		}
 
-	ps := androidpublisher.NewPurchasesSubscriptionsService(service)
-	err = ps.Refund(packageName, subscriptionID, token).Context(ctx).Do()
+		ps := androidpublisher.NewPurchasesSubscriptionsService(service)
+		return ps.Refund(packageName, subscriptionID, token).Context(ctx).Do()
+	})
 
-	return err
+	return mapAPIError(err)
 }
 
 // RevokeSubscription refunds and immediately revokes a user's subscription purchase.
 // Access to the subscription will be terminated immediately and it will stop recurring.
 func (c *Client) RevokeSubscription(ctx context.Context, packageName string, subscriptionID string, token string) error {
-	service, err := androidpublisher.New(c.httpCli)
-	if err != nil {
-		return err
-	}
+	err := withRetry(ctx, c.retry, func() error {
+		service, err := androidpublisher.New(c.httpCli)
+		if err != nil {
+			return err
+		}
 
-	ps := androidpublisher.NewPurchasesSubscriptionsService(service)
-	err = ps.Revoke(packageName, subscriptionID, token).Context(ctx).Do()
+		ps := androidpublisher.NewPurchasesSubscriptionsService(service)
+		return ps.Revoke(packageName, subscriptionID, token).Context(ctx).Do()
+	})
 
-	return err
+	return mapAPIError(err)
 }
 
 // VerifySignature verifies in app billing signature.
 // You need to prepare a public key for your Android app's in app billing
 // at https://play.google.com/apps/publish/
+//
+// This is a thin, backwards-compatible wrapper around
+// VerifySignatureWithOptions using SHA-1, the Play Billing Library v2
+// format. Billing Library 5+ and Play Integrity sign receipts with SHA-256;
+// use VerifySignatureWithOptions for those, and for key rotation.
 func VerifySignature(base64EncodedPublicKey string, receipt []byte, signature string) (isValid bool, err error) {
-	// prepare public key
-	decodedPublicKey, err := base64.StdEncoding.DecodeString(base64EncodedPublicKey)
-	if err != nil {
-		return false, fmt.Errorf("failed to decode public key")
-	}
-	publicKeyInterface, err := x509.ParsePKIXPublicKey(decodedPublicKey)
-	if err != nil {
-		return false, fmt.Errorf("failed to parse public key")
-	}
-	publicKey, _ := publicKeyInterface.(*rsa.PublicKey)
-
-	// generate hash value from receipt
-	hasher := sha1.New()
-	hasher.Write(receipt)
-	hashedReceipt := hasher.Sum(nil)
-
-	// decode signature
-	decodedSignature, err := base64.StdEncoding.DecodeString(signature)
+	publicKey, err := ParsePublicKey(base64EncodedPublicKey)
 	if err != nil {
-		return false, fmt.Errorf("failed to decode signature")
+		return false, err
 	}
 
-	// verify
-	if err := rsa.VerifyPKCS1v15(publicKey, crypto.SHA1, hashedReceipt, decodedSignature); err != nil {
+	valid, err := VerifySignatureWithOptions(receipt, signature, VerifyOptions{
+		PublicKeys: []*rsa.PublicKey{publicKey},
+		Hash:       crypto.SHA1,
+	})
+	if errors.Is(err, ErrSignatureMismatch) {
 		return false, nil
 	}
 
-	return true, nil
+	return valid, err
 }